@@ -0,0 +1,218 @@
+// Copyright 2012 Daniel Connelly.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rtreego
+
+import (
+	"math"
+	"sort"
+)
+
+// BulkLoad builds a Tree from a static slice of objects in O(n log n)
+// using Sort-Tile-Recursive (STR) packing, instead of n sequential Insert
+// calls that each descend the tree and may split nodes.  The resulting Tree's
+// root and internal nodes are ordinary Tree/node structures, so
+// subsequent Insert/NearestNeighbor/SearchIntersect work unchanged.
+// Dimensionality is taken from objects[0], and fan-out is always
+// DefaultMinChildren/DefaultMaxChildren; TreeOptions (WithMetric,
+// WithTolerance) set the returned Tree's metric and tolerance. BulkLoad panics, like NewTree would
+// error, if objects is empty (there's no dimensionality to infer) or its
+// bounding boxes don't all agree on one.
+//
+// STR proceeds by recursively tiling the objects across the tree's Dims
+// axes, widest extent first: given n leaf rectangles and node capacity M,
+// it computes P = ceil(n/M) leaf pages, slices the current axis into
+// ceil(P^(1/remaining-axes)) groups sorted by that axis's center, and
+// recurses on the remaining axes within each group. The same procedure is
+// then applied, level by level, to the parent bounding boxes it produces
+// until a single root remains.
+func BulkLoad(objects []Spatial, opts ...TreeOption) *Tree {
+	if len(objects) == 0 {
+		panic(DimError{1, 0})
+	}
+	dims := objects[0].Bounds().Dims()
+
+	t, err := NewTree(dims, DefaultMinChildren, DefaultMaxChildren, opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	leaves := make([]packItem, len(objects))
+	for i, obj := range objects {
+		bb := obj.Bounds()
+		if err := t.checkDims(bb); err != nil {
+			panic(err)
+		}
+		leaves[i] = packItem{bb: bb, entry: entry{bb: bb, obj: obj}}
+	}
+
+	axes := axesByExtent(leaves)
+
+	nodes := packLevel(leaves, axes, t.MaxChildren, t.MinChildren, true)
+	for len(nodes) > 1 {
+		items := make([]packItem, len(nodes))
+		for i, n := range nodes {
+			bb, err := n.computeBoundingBox(dims)
+			if err != nil {
+				panic(err)
+			}
+			items[i] = packItem{bb: bb, entry: entry{bb: bb, child: n}}
+		}
+		nodes = packLevel(items, axes, t.MaxChildren, t.MinChildren, false)
+	}
+
+	t.root = nodes[0]
+	t.root.parent = nil
+	fixParents(t.root)
+	t.size = len(objects)
+	return t
+}
+
+// packItem is a leaf rectangle or a parent's child node awaiting
+// placement into a node during STR packing.
+type packItem struct {
+	bb    *Rect
+	entry entry
+}
+
+// axesByExtent returns the axis indices of items' overall bounding box,
+// widest extent first, so STR tiles along the axis most likely to spread
+// the data out before the others.
+func axesByExtent(items []packItem) []int {
+	dims := items[0].bb.Dims()
+	extent := make([]float64, dims)
+	lo := append(Point(nil), items[0].bb.P...)
+	hi := append(Point(nil), items[0].bb.Q...)
+	for _, it := range items[1:] {
+		for i := 0; i < dims; i++ {
+			if it.bb.P[i] < lo[i] {
+				lo[i] = it.bb.P[i]
+			}
+			if it.bb.Q[i] > hi[i] {
+				hi[i] = it.bb.Q[i]
+			}
+		}
+	}
+	for i := 0; i < dims; i++ {
+		extent[i] = hi[i] - lo[i]
+	}
+	axes := make([]int, dims)
+	for i := range axes {
+		axes[i] = i
+	}
+	sort.Slice(axes, func(i, j int) bool { return extent[axes[i]] > extent[axes[j]] })
+	return axes
+}
+
+// packLevel groups items into nodes of at most groupSize entries each (and,
+// bar a too-small input overall, at least minSize), via recursive STR
+// tiling across axes.  strTile's axis slicing can leave an underfull group
+// at the boundary between two slabs that neither slab's own chunking sees,
+// so the groups it returns are redistributed as a final pass before nodes
+// are built.
+func packLevel(items []packItem, axes []int, groupSize, minSize int, leaf bool) []*node {
+	groups := redistributeUnderfull(strTile(items, axes, groupSize), minSize)
+	nodes := make([]*node, len(groups))
+	for i, g := range groups {
+		n := &node{leaf: leaf}
+		for _, it := range g {
+			n.entries = append(n.entries, it.entry)
+		}
+		nodes[i] = n
+	}
+	return nodes
+}
+
+// strTile recursively slices items along axes (widest extent first),
+// sorting each slice by its axis's center before tiling the next axis,
+// and returns the groups produced by the final axis, each of at most
+// groupSize items.
+func strTile(items []packItem, axes []int, groupSize int) [][]packItem {
+	if len(axes) == 0 || len(items) <= groupSize {
+		return chunk(items, groupSize)
+	}
+
+	axis := axes[0]
+	sort.Slice(items, func(i, j int) bool {
+		return center(items[i].bb, axis) < center(items[j].bb, axis)
+	})
+
+	numGroups := ceilDiv(len(items), groupSize)
+	sliceCount := int(math.Ceil(math.Pow(float64(numGroups), 1/float64(len(axes)))))
+	if sliceCount < 1 {
+		sliceCount = 1
+	}
+	sliceSize := ceilDiv(len(items), sliceCount)
+
+	var result [][]packItem
+	for i := 0; i < len(items); i += sliceSize {
+		end := i + sliceSize
+		if end > len(items) {
+			end = len(items)
+		}
+		result = append(result, strTile(items[i:end], axes[1:], groupSize)...)
+	}
+	return result
+}
+
+func center(r *Rect, axis int) float64 {
+	return (r.P[axis] + r.Q[axis]) / 2
+}
+
+func chunk(items []packItem, size int) [][]packItem {
+	var chunks [][]packItem
+	for i := 0; i < len(items); i += size {
+		end := i + size
+		if end > len(items) {
+			end = len(items)
+		}
+		chunks = append(chunks, items[i:end])
+	}
+	return chunks
+}
+
+// redistributeUnderfull walks groups left to right and, whenever one holds
+// fewer than minSize items, merges it with a neighbor and splits the
+// combined items evenly between the two -- Guttman's forced-fill rule,
+// applied across STR's group boundaries rather than within a single node's
+// split, so that a short remainder group (or short final slab) never
+// becomes an underfull node on its own. A lone group below minSize is left
+// as-is: that can only happen when this level has fewer than minSize items
+// in total, which only the root node is allowed to violate.
+func redistributeUnderfull(groups [][]packItem, minSize int) [][]packItem {
+	for i := 0; i < len(groups); i++ {
+		if len(groups) < 2 || len(groups[i]) >= minSize {
+			continue
+		}
+		j := i - 1
+		if j < 0 {
+			j = i + 1
+		}
+		lo, hi := i, j
+		if hi < lo {
+			lo, hi = hi, lo
+		}
+		merged := append(append([]packItem{}, groups[lo]...), groups[hi]...)
+		half := len(merged) - len(merged)/2
+		groups[lo] = merged[:half]
+		groups[hi] = merged[half:]
+	}
+	return groups
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// fixParents sets each child node's parent pointer to match where it
+// actually ended up, since packLevel builds nodes bottom-up without
+// threading parent links as it goes.
+func fixParents(n *node) {
+	for _, e := range n.entries {
+		if e.child != nil {
+			e.child.parent = n
+			fixParents(e.child)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2012 Daniel Connelly.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rtreego
+
+import "testing"
+
+func TestBulkLoad(t *testing.T) {
+	objs := make([]Spatial, 37)
+	for i := range objs {
+		objs[i] = point2D{float64(i), float64(i%5) * 1.5}
+	}
+
+	tree := BulkLoad(objs)
+	if tree.Size() != len(objs) {
+		t.Fatalf("Size() = %d, want %d", tree.Size(), len(objs))
+	}
+
+	got, err := tree.SearchIntersect(mustBounds(tree.root))
+	if err != nil {
+		t.Fatalf("SearchIntersect: %v", err)
+	}
+	if len(got) != len(objs) {
+		t.Fatalf("SearchIntersect over the whole tree returned %d objects, want %d", len(got), len(objs))
+	}
+}
+
+func TestBulkLoadEnforcesMinChildren(t *testing.T) {
+	// Sweep a range of input sizes, including ones that leave an awkward
+	// remainder for STR's tiling, and confirm every non-root node still
+	// respects MinChildren -- the invariant a plain items[i:i+size] slice
+	// (see strTile/chunk) would violate for a short final group.
+	for n := 1; n <= 60; n++ {
+		objs := make([]Spatial, n)
+		for i := range objs {
+			objs[i] = point2D{float64(i), float64(i % 7)}
+		}
+		tree := BulkLoad(objs)
+
+		var walk func(nd *node)
+		walk = func(nd *node) {
+			if nd != tree.root && len(nd.entries) < tree.MinChildren {
+				t.Errorf("n=%d: node has %d entries, want >= MinChildren (%d)", n, len(nd.entries), tree.MinChildren)
+			}
+			if len(nd.entries) > tree.MaxChildren {
+				t.Errorf("n=%d: node has %d entries, want <= MaxChildren (%d)", n, len(nd.entries), tree.MaxChildren)
+			}
+			for _, e := range nd.entries {
+				if e.child != nil {
+					walk(e.child)
+				}
+			}
+		}
+		walk(tree.root)
+	}
+}
+
+func TestBulkLoadEmptyPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("BulkLoad(nil) did not panic")
+		}
+	}()
+	BulkLoad(nil)
+}
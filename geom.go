@@ -18,8 +18,23 @@ func (err DistError) Error() string {
 	return "rtreego: improper distance"
 }
 
-// Point represents a point in 3-dimensional Euclidean space.
-type Point [Dim]float64
+// DimError is returned when an operation is attempted between two
+// geometries (points, rectangles, or a geometry and a Tree) whose
+// dimensionalities do not match.
+type DimError struct {
+	Expected, Actual int
+}
+
+func (err DimError) Error() string {
+	return fmt.Sprintf("rtreego: dimension mismatch: expected %d, got %d", err.Expected, err.Actual)
+}
+
+// Point represents a point in an arbitrary number of dimensions.  Unlike
+// earlier versions of this package, the dimensionality of a Point is not
+// fixed at compile time: it is simply len(p).  This lets a single process
+// host trees of different dimensionalities (e.g. a 2D geo index alongside
+// a 3D mesh index) without forking the package.
+type Point []float64
 
 // Dist computes the Euclidean distance between two points p and q.
 func (p Point) Dist(q Point) float64 {
@@ -37,7 +52,7 @@ func (p Point) Dot(q Point) float64 {
 
 // sum computes p + q
 func (p Point) Add(q Point) Point {
-	var sum Point
+	sum := make(Point, len(p))
 	for i := range p {
 		sum[i] = p[i] + q[i]
 	}
@@ -46,7 +61,7 @@ func (p Point) Add(q Point) Point {
 
 // sub computes p - q
 func (p Point) Sub(q Point) Point {
-	var diff Point
+	diff := make(Point, len(p))
 	for i := range p {
 		diff[i] = p[i] - q[i]
 	}
@@ -55,7 +70,7 @@ func (p Point) Sub(q Point) Point {
 
 // scale computes a * p
 func (p Point) Scale(a float64) Point {
-	var s Point
+	s := make(Point, len(p))
 	for i := range p {
 		s[i] = a * p[i]
 	}
@@ -67,7 +82,7 @@ func (p Point) Norm() float64 {
 }
 
 func (p Point) Unit() Point {
-	return p.Scale(1/p.Norm())
+	return p.Scale(1 / p.Norm())
 }
 
 // projection of p in the q direction
@@ -75,6 +90,20 @@ func (p Point) Proj(q Point) Point {
 	return q.Unit().Scale(p.Dot(q))
 }
 
+// Dims reports the dimensionality of p.
+func (p Point) Dims() int {
+	return len(p)
+}
+
+// checkDims returns a DimError if p and q do not have the same
+// dimensionality.
+func (p Point) checkDims(q Point) error {
+	if len(p) != len(q) {
+		return DimError{len(p), len(q)}
+	}
+	return nil
+}
+
 // minDist computes the square of the distance from a point to a rectangle.
 // If the point is contained in the rectangle then the distance is zero.
 //
@@ -145,34 +174,56 @@ func (p Point) minMaxDist(r *Rect) float64 {
 }
 
 func (p Point) String() string {
-	var s [Dim]string
-	for i := 0; i < Dim; i++ {
+	s := make([]string, len(p))
+	for i := range p {
 		s[i] = fmt.Sprintf("%f", p[i])
 	}
-	return fmt.Sprintf("(%s)", strings.Join(s[:], ", "))
+	return fmt.Sprintf("(%s)", strings.Join(s, ", "))
 }
 
-// Rect represents a subset of 3-dimensional Euclidean space of the form
-// [a1, b1] x [a2, b2] x ... x [an, bn], where ai < bi for all 1 <= i <= n.
+// Rect represents a subset of n-dimensional Euclidean space of the form
+// [a1, b1] x [a2, b2] x ... x [an, bn], where ai <= bi for all 1 <= i <= n.
+// The dimensionality n of a Rect is len(r.P) and is fixed once the Rect is
+// constructed; P and Q always have equal length.
 type Rect struct {
 	P, Q Point // Enforced by NewRect: p[i] <= q[i] for all i.
 }
 
 func (r *Rect) String() string {
-	var s [Dim]string
+	s := make([]string, len(r.P))
 	for i, a := range r.P {
 		b := r.Q[i]
 		s[i] = fmt.Sprintf("[%.2f, %.2f]", a, b)
 	}
-	return strings.Join(s[:], "x")
+	return strings.Join(s, "x")
+}
+
+// Dims reports the dimensionality of r.
+func (r *Rect) Dims() int {
+	return len(r.P)
+}
+
+// checkDims returns a DimError if r and r2 do not have the same
+// dimensionality.
+func (r *Rect) checkDims(r2 *Rect) error {
+	if len(r.P) != len(r2.P) {
+		return DimError{len(r.P), len(r2.P)}
+	}
+	return nil
 }
 
 // NewRect constructs and returns a pointer to a Rect given a corner point and
 // the lengths of each dimension.  The point p should be the most-negative point
 // on the rectangle (in every dimension) and every length should be positive.
-func NewRect(p Point, lengths [Dim]float64) (r Rect, err error) {
+// NewRect returns an error if lengths does not have the same dimensionality
+// as p.
+func NewRect(p Point, lengths []float64) (r Rect, err error) {
+	if len(p) != len(lengths) {
+		return r, DimError{len(p), len(lengths)}
+	}
 	r.P = p
-	r.Q = lengths
+	r.Q = make(Point, len(lengths))
+	copy(r.Q, lengths)
 	for i, l := range r.Q {
 		if l <= 0 {
 			return r, DistError(l)
@@ -206,39 +257,100 @@ func (r *Rect) margin() float64 {
 		b := r.Q[i]
 		sum += b - a
 	}
-	return 4.0 * sum
+	return math.Pow(2, float64(len(r.P)-1)) * sum
+}
+
+// Tolerance is the default slack, in coordinate units, applied by
+// ContainsPoint, ContainsRect, and Intersect when deciding whether a point
+// or rectangle falls on the boundary of another rectangle.  It defaults to
+// zero (exact comparisons, the original behavior); callers working with
+// noisy data (GPS snapping, mesh cutting) can raise it package-wide, or
+// set a tolerance per Tree via WithTolerance.
+var Tolerance float64
+
+// ContainsPoint tests whether p is located inside or within Tolerance of
+// the boundary of r.  It returns a DimError if p and r do not have the
+// same dimensionality.
+func (r *Rect) ContainsPoint(p Point) (bool, error) {
+	return r.containsPoint(p, Tolerance)
 }
 
-// ContainsPoint tests whether p is located inside or on the boundary of r.
-func (r *Rect) ContainsPoint(p Point) bool {
+func (r *Rect) containsPoint(p Point, eps float64) (bool, error) {
+	if len(p) != len(r.P) {
+		return false, DimError{len(r.P), len(p)}
+	}
 	for i, a := range p {
-		// p is contained in (or on) r if and only if p <= a <= q for
-		// every dimension.
-		if a < r.P[i] || a > r.Q[i] {
-			return false
+		// p is contained in (or within eps of) r if and only if
+		// p - eps <= a <= q + eps for every dimension.
+		if a < r.P[i]-eps || a > r.Q[i]+eps {
+			return false, nil
 		}
 	}
 
-	return true
+	return true, nil
 }
 
-// containsRect tests whether r2 is is located inside r1.
-func (r1 *Rect) ContainsRect(r2 *Rect) bool {
+// ContainsRect tests whether r2 is located inside, or within Tolerance of
+// the boundary of, r1.  It returns a DimError if r1 and r2 do not have the
+// same dimensionality.
+func (r1 *Rect) ContainsRect(r2 *Rect) (bool, error) {
+	return r1.containsRect(r2, Tolerance)
+}
+
+func (r1 *Rect) containsRect(r2 *Rect, eps float64) (bool, error) {
+	if err := r1.checkDims(r2); err != nil {
+		return false, err
+	}
 	for i, a1 := range r1.P {
 		b1, a2, b2 := r1.Q[i], r2.P[i], r2.Q[i]
 		// enforced by constructor: a1 <= b1 and a2 <= b2.
 		// so containment holds if and only if a1 <= a2 <= b2 <= b1
-		// for every dimension.
-		if a1 > a2 || b2 > b1 {
-			return false
+		// for every dimension, with eps of slack on either side.
+		if a1-eps > a2 || b2 > b1+eps {
+			return false, nil
 		}
 	}
 
-	return true
+	return true, nil
+}
+
+// Touches reports whether r and r2 share a boundary within Tolerance:
+// every axis has no true gap larger than Tolerance between them, and at
+// least one axis overlaps by no more than Tolerance -- i.e. they meet at
+// (or near) a shared edge/corner rather than through interior overlap.
+//
+// This is deliberately independent of Intersect/intersects, which treats
+// an exact shared boundary (gap == 0) as non-overlap by design; gating on
+// that here would reject Touches' own textbook case.
+func (r *Rect) Touches(r2 *Rect) bool {
+	if err := r.checkDims(r2); err != nil {
+		return false
+	}
+	eps := Tolerance
+	touching := false
+	for i := range r.P {
+		lo := math.Max(r.P[i], r2.P[i])
+		hi := math.Min(r.Q[i], r2.Q[i])
+		overlap := hi - lo
+		if overlap < -eps {
+			// a genuine gap on this axis: the rects don't meet at all.
+			return false
+		}
+		if overlap <= eps {
+			touching = true
+		}
+	}
+	return touching
 }
 
-func (r1 *Rect) enlarge(r2 *Rect) {
-	for i := 0; i < Dim; i++ {
+// enlarge grows r1 in place to be the smallest rectangle containing both
+// r1 and r2.  It returns a DimError if r1 and r2 do not have the same
+// dimensionality.
+func (r1 *Rect) enlarge(r2 *Rect) error {
+	if err := r1.checkDims(r2); err != nil {
+		return err
+	}
+	for i := range r1.P {
 		if r1.P[i] > r2.P[i] {
 			r1.P[i] = r2.P[i]
 		}
@@ -246,11 +358,13 @@ func (r1 *Rect) enlarge(r2 *Rect) {
 			r1.Q[i] = r2.Q[i]
 		}
 	}
+	return nil
 }
 
-// intersect computes the intersection of two rectangles.  If no intersection
-// exists, the intersection is nil.
-func Intersect(r1, r2 *Rect) bool {
+// Intersect reports whether r1 and r2 overlap, or come within Tolerance of
+// overlapping.  It returns a DimError if r1 and r2 do not have the same
+// dimensionality.
+func Intersect(r1, r2 *Rect) (bool, error) {
 	// There are four cases of overlap:
 	//
 	//     1.  a1------------b1
@@ -278,19 +392,26 @@ func Intersect(r1, r2 *Rect) bool {
 	//        a2------b2
 	//
 	// Enforced by constructor: a1 <= b1 and a2 <= b2.  So we can just
-	// check the endpoints.
+	// check the endpoints, with eps of slack allowing a near-miss gap to
+	// still count as overlap.
+	return intersects(r1, r2, Tolerance)
+}
 
-	for i := 0; i < Dim; i++ {
-		if r2.Q[i] <= r1.P[i] || r1.Q[i] <= r2.P[i] {
-			return false
+func intersects(r1, r2 *Rect, eps float64) (bool, error) {
+	if err := r1.checkDims(r2); err != nil {
+		return false, err
+	}
+	for i := range r1.P {
+		if r2.Q[i]+eps <= r1.P[i] || r1.Q[i]+eps <= r2.P[i] {
+			return false, nil
 		}
 	}
-	return true
+	return true, nil
 }
 
 // ToRect constructs a rectangle containing p with side lengths 2*tol.
 func (p Point) ToRect(tol float64) *Rect {
-	var r Rect
+	r := Rect{P: make(Point, len(p)), Q: make(Point, len(p))}
 	for i := range p {
 		r.P[i] = p[i] - tol
 		r.Q[i] = p[i] + tol
@@ -298,14 +419,129 @@ func (p Point) ToRect(tol float64) *Rect {
 	return &r
 }
 
-func initBoundingBox(r, r1, r2 *Rect) {
-	*r = *r1
-	r.enlarge(r2)
+// NewRectFromCorners builds the canonical Rect spanning the two given
+// corners, without requiring the caller to pre-sort them per axis or
+// compute side lengths the way NewRect does.
+func NewRectFromCorners(p, q Point) (*Rect, error) {
+	if len(p) != len(q) {
+		return nil, DimError{len(p), len(q)}
+	}
+	r := &Rect{P: append(Point(nil), p...), Q: append(Point(nil), q...)}
+	return r.Canon(), nil
 }
 
-// boundingBox constructs the smallest rectangle containing both r1 and r2.
-func boundingBox(r1, r2 *Rect) *Rect {
-	var r Rect
-	initBoundingBox(&r, r1, r2)
-	return &r
+// mustSameDims panics with a DimError if r and r2 do not have the same
+// dimensionality.  It backs the Rect algebra methods below, which mirror
+// image.Rectangle's signatures (and so, like image.Rectangle, assume
+// their operands are already compatible rather than returning an error).
+func (r *Rect) mustSameDims(r2 *Rect) {
+	if err := r.checkDims(r2); err != nil {
+		panic(err)
+	}
+}
+
+// Union returns the smallest Rect containing both r and r2.
+func (r *Rect) Union(r2 *Rect) *Rect {
+	r.mustSameDims(r2)
+	u := Rect{P: append(Point(nil), r.P...), Q: append(Point(nil), r.Q...)}
+	u.enlarge(r2) // error impossible: dims already checked above
+	return &u
+}
+
+// Intersection returns the overlapping region of r and r2, or nil if they
+// are disjoint.  Rects that only share a boundary give a zero-width Rect
+// (which Empty reports as empty), not nil.  Renamed from image.Rectangle.Intersect to avoid
+// colliding with the package-level Intersect function.
+func (r *Rect) Intersection(r2 *Rect) *Rect {
+	r.mustSameDims(r2)
+	p := make(Point, len(r.P))
+	q := make(Point, len(r.Q))
+	for i := range r.P {
+		p[i] = math.Max(r.P[i], r2.P[i])
+		q[i] = math.Min(r.Q[i], r2.Q[i])
+		if p[i] > q[i] {
+			return nil
+		}
+	}
+	return &Rect{P: p, Q: q}
+}
+
+// Empty reports whether r has zero (or negative) extent along any axis,
+// i.e. P[i] >= Q[i] for some i, as image.Rectangle.Empty does.  A Rect
+// that Intersection or Inset collapses to a line or a point on some axis
+// is therefore Empty, even though its closed extent still holds points.
+func (r *Rect) Empty() bool {
+	for i := range r.P {
+		if r.P[i] >= r.Q[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// Eq reports whether r and r2 describe the same rectangle.
+func (r *Rect) Eq(r2 *Rect) bool {
+	if len(r.P) != len(r2.P) {
+		return false
+	}
+	for i := range r.P {
+		if r.P[i] != r2.P[i] || r.Q[i] != r2.Q[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Canon returns r with each axis's corners swapped so that P[i] <= Q[i],
+// letting callers build a Rect from two arbitrary corners without
+// pre-sorting them.
+func (r *Rect) Canon() *Rect {
+	p := make(Point, len(r.P))
+	q := make(Point, len(r.Q))
+	for i := range r.P {
+		if r.P[i] <= r.Q[i] {
+			p[i], q[i] = r.P[i], r.Q[i]
+		} else {
+			p[i], q[i] = r.Q[i], r.P[i]
+		}
+	}
+	return &Rect{P: p, Q: q}
+}
+
+// Add returns r translated by d.  It panics if d's dimensionality doesn't
+// match r's.
+func (r *Rect) Add(d Point) *Rect {
+	r.mustSameDims(&Rect{P: d, Q: d})
+	return &Rect{P: r.P.Add(d), Q: r.Q.Add(d)}
+}
+
+// Sub returns r translated by -d.  It panics if d's dimensionality
+// doesn't match r's.
+func (r *Rect) Sub(d Point) *Rect {
+	r.mustSameDims(&Rect{P: d, Q: d})
+	return &Rect{P: r.P.Sub(d), Q: r.Q.Sub(d)}
+}
+
+// Inset shrinks (or, for negative d, grows) r by d on every side. If this
+// would make an axis empty, that axis collapses to its midpoint, matching
+// image.Rectangle.Inset's behavior.
+func (r *Rect) Inset(d float64) *Rect {
+	p := make(Point, len(r.P))
+	q := make(Point, len(r.Q))
+	for i := range r.P {
+		p[i] = r.P[i] + d
+		q[i] = r.Q[i] - d
+		if p[i] > q[i] {
+			mid := (r.P[i] + r.Q[i]) / 2
+			p[i], q[i] = mid, mid
+		}
+	}
+	return &Rect{P: p, Q: q}
+}
+
+// Overlaps reports whether r and r2 overlap.  It is a method alias for
+// the package-level Intersect function, so at zero Tolerance rects that
+// only share a boundary do not overlap.
+func (r *Rect) Overlaps(r2 *Rect) (bool, error) {
+	return Intersect(r, r2)
 }
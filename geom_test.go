@@ -0,0 +1,210 @@
+// Copyright 2012 Daniel Connelly.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rtreego
+
+import (
+	"math"
+	"testing"
+)
+
+func mustRect(t *testing.T, p Point, lengths []float64) Rect {
+	t.Helper()
+	r, err := NewRect(p, lengths)
+	if err != nil {
+		t.Fatalf("NewRect(%v, %v): %v", p, lengths, err)
+	}
+	return r
+}
+
+func TestContainsPointTolerance(t *testing.T) {
+	r := mustRect(t, Point{0, 0}, []float64{1, 1})
+
+	orig := Tolerance
+	defer func() { Tolerance = orig }()
+
+	Tolerance = 0
+	if ok, _ := r.ContainsPoint(Point{1.0000001, 0.5}); ok {
+		t.Fatalf("expected point just outside the boundary to be rejected at Tolerance=0")
+	}
+
+	Tolerance = 1e-3
+	if ok, _ := r.ContainsPoint(Point{1.0000001, 0.5}); !ok {
+		t.Fatalf("expected point within Tolerance of the boundary to be accepted")
+	}
+}
+
+func TestIntersectTolerance(t *testing.T) {
+	r1 := mustRect(t, Point{0, 0}, []float64{1, 1})
+	r2 := mustRect(t, Point{1.001, 0}, []float64{1, 1})
+
+	orig := Tolerance
+	defer func() { Tolerance = orig }()
+
+	Tolerance = 0
+	if ok, _ := Intersect(&r1, &r2); ok {
+		t.Fatalf("expected a real gap to not intersect at Tolerance=0")
+	}
+
+	Tolerance = 0.01
+	if ok, _ := Intersect(&r1, &r2); !ok {
+		t.Fatalf("expected a near-miss gap smaller than Tolerance to count as intersecting")
+	}
+}
+
+func TestTouchesExactBoundary(t *testing.T) {
+	r1 := mustRect(t, Point{0, 0}, []float64{1, 1})
+	r2 := mustRect(t, Point{1, 0}, []float64{1, 1})
+
+	if !r1.Touches(&r2) {
+		t.Fatalf("expected rects sharing the boundary x=1 to Touch")
+	}
+	if !r2.Touches(&r1) {
+		t.Fatalf("Touches should be symmetric")
+	}
+}
+
+func TestTouchesFalseCases(t *testing.T) {
+	base := mustRect(t, Point{0, 0}, []float64{1, 1})
+
+	separate := mustRect(t, Point{5, 5}, []float64{1, 1})
+	if base.Touches(&separate) {
+		t.Fatalf("disjoint rects should not Touch")
+	}
+
+	overlapping := mustRect(t, Point{0.5, 0.5}, []float64{1, 1})
+	if base.Touches(&overlapping) {
+		t.Fatalf("rects overlapping through their interior should not Touch")
+	}
+}
+
+func TestOnSegmentDistanceSum(t *testing.T) {
+	// For a point p on the segment [rp1, rp2], dist(rp1,p) + dist(p,rp2)
+	// should equal dist(rp1,rp2); Segment.PreciseDistance should report
+	// zero for such a p. Check this across a range of coordinate
+	// magnitudes, from sub-unit to large.
+	magnitudes := []float64{0.001, 1, 1000, 1e6}
+	for _, m := range magnitudes {
+		rp1 := Point{-m, -m}
+		rp2 := Point{m, m * 0.5}
+		seg := Segment{A: rp1, B: rp2}
+
+		for _, frac := range []float64{0, 0.25, 0.5, 0.75, 1} {
+			p := rp1.Add(rp2.Sub(rp1).Scale(frac))
+
+			sum := rp1.Dist(p) + p.Dist(rp2)
+			want := rp1.Dist(rp2)
+			if math.Abs(sum-want) > 1e-6*math.Max(1, want) {
+				t.Errorf("magnitude %v frac %v: dist(rp1,p)+dist(p,rp2) = %v, want %v", m, frac, sum, want)
+			}
+
+			if d := seg.PreciseDistance(p); d > 1e-6*math.Max(1, m) {
+				t.Errorf("magnitude %v frac %v: PreciseDistance(p) = %v, want ~0", m, frac, d)
+			}
+		}
+	}
+}
+
+func TestRectUnion(t *testing.T) {
+	r1 := mustRect(t, Point{0, 0}, []float64{1, 1})
+	r2 := mustRect(t, Point{2, -1}, []float64{1, 3})
+
+	u := r1.Union(&r2)
+	want := mustRect(t, Point{0, -1}, []float64{3, 3})
+	if !u.Eq(&want) {
+		t.Fatalf("Union = %v, want %v", u, &want)
+	}
+}
+
+func TestRectIntersection(t *testing.T) {
+	r1 := mustRect(t, Point{0, 0}, []float64{2, 2})
+	r2 := mustRect(t, Point{1, 1}, []float64{2, 2})
+
+	i := r1.Intersection(&r2)
+	want := mustRect(t, Point{1, 1}, []float64{1, 1})
+	if i == nil || !i.Eq(&want) {
+		t.Fatalf("Intersection = %v, want %v", i, &want)
+	}
+
+	disjoint := mustRect(t, Point{10, 10}, []float64{1, 1})
+	if got := r1.Intersection(&disjoint); got != nil {
+		t.Fatalf("Intersection of disjoint rects = %v, want nil", got)
+	}
+}
+
+func TestRectEmpty(t *testing.T) {
+	r := mustRect(t, Point{0, 0}, []float64{1, 1})
+	if r.Empty() {
+		t.Fatalf("a normal rect should not be Empty")
+	}
+
+	disjoint := mustRect(t, Point{10, 10}, []float64{1, 1})
+	collapsed := r.Intersection(&disjoint)
+	if collapsed != nil {
+		t.Fatalf("expected nil Intersection, got %v", collapsed)
+	}
+
+	// Inset past a rect's own half-width collapses that axis to its
+	// midpoint, leaving P == Q there -- which Empty counts as empty.
+	pinched := r.Inset(10)
+	if !pinched.Empty() {
+		t.Fatalf("Inset far past the rect's extent should leave it Empty, got %v", pinched)
+	}
+}
+
+func TestRectEq(t *testing.T) {
+	r1 := mustRect(t, Point{0, 0}, []float64{1, 1})
+	r2 := mustRect(t, Point{0, 0}, []float64{1, 1})
+	r3 := mustRect(t, Point{0, 0}, []float64{2, 1})
+
+	if !r1.Eq(&r2) {
+		t.Fatalf("identical rects should be Eq")
+	}
+	if r1.Eq(&r3) {
+		t.Fatalf("rects with different extents should not be Eq")
+	}
+}
+
+func TestRectCanon(t *testing.T) {
+	backwards := &Rect{P: Point{1, 0}, Q: Point{0, 1}}
+	canon := backwards.Canon()
+	want := mustRect(t, Point{0, 0}, []float64{1, 1})
+	if !canon.Eq(&want) {
+		t.Fatalf("Canon = %v, want %v", canon, &want)
+	}
+}
+
+func TestRectAddSub(t *testing.T) {
+	r := mustRect(t, Point{0, 0}, []float64{1, 1})
+	moved := r.Add(Point{3, -2})
+	want := mustRect(t, Point{3, -2}, []float64{1, 1})
+	if !moved.Eq(&want) {
+		t.Fatalf("Add = %v, want %v", moved, &want)
+	}
+	if back := moved.Sub(Point{3, -2}); !back.Eq(&r) {
+		t.Fatalf("Sub did not undo Add: got %v, want %v", back, &r)
+	}
+}
+
+func TestRectInset(t *testing.T) {
+	r := mustRect(t, Point{0, 0}, []float64{4, 4})
+	in := r.Inset(1)
+	want := mustRect(t, Point{1, 1}, []float64{2, 2})
+	if !in.Eq(&want) {
+		t.Fatalf("Inset(1) = %v, want %v", in, &want)
+	}
+}
+
+func TestRectOverlaps(t *testing.T) {
+	r1 := mustRect(t, Point{0, 0}, []float64{1, 1})
+	r2 := mustRect(t, Point{0.5, 0.5}, []float64{1, 1})
+	r3 := mustRect(t, Point{5, 5}, []float64{1, 1})
+
+	if ok, err := r1.Overlaps(&r2); err != nil || !ok {
+		t.Fatalf("Overlaps = %v, %v, want true, nil", ok, err)
+	}
+	if ok, err := r1.Overlaps(&r3); err != nil || ok {
+		t.Fatalf("Overlaps = %v, %v, want false, nil", ok, err)
+	}
+}
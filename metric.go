@@ -0,0 +1,215 @@
+// Copyright 2012 Daniel Connelly.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rtreego
+
+import "math"
+
+// EarthRadiusMeters is the mean radius of the Earth in meters, used as the
+// default for Spherical when no other radius is configured.
+const EarthRadiusMeters = 6371000.0
+
+// Metric abstracts the distance measurements the tree needs in order to
+// prune its search: the distance from a query point to a rectangle, and
+// the distance between two rectangles.
+// Swapping the Metric lets the same Tree implementation support both
+// planar and geographic (lat/lon) data.
+type Metric interface {
+	// PointRect returns the distance from p to the nearest point of r.
+	PointRect(p Point, r *Rect) float64
+	// RectRect returns the distance between the nearest points of a and b.
+	RectRect(a, b *Rect) float64
+}
+
+// RectMerger is implemented by a Metric that needs to override how two
+// bounding boxes are combined into one, e.g. to pick the shorter of two
+// possible unions when longitude wraps the antimeridian.  Metrics that
+// don't implement RectMerger get the default Euclidean enlarge/union.
+type RectMerger interface {
+	MergeRect(a, b *Rect) (*Rect, error)
+}
+
+// Euclidean is the Metric used by a Tree constructed without
+// WithMetric; it reproduces the distance formulas rtreego has always used.
+type Euclidean struct{}
+
+// PointRect returns the Euclidean distance from p to its nearest point in
+// r (the square root of minDist in Roussopoulos et al., so that it's
+// directly comparable to Shape.PreciseDistance).
+func (Euclidean) PointRect(p Point, r *Rect) float64 {
+	return math.Sqrt(p.minDist(r))
+}
+
+// RectRect returns the Euclidean distance between the nearest points of a
+// and b (zero if they overlap).
+func (Euclidean) RectRect(a, b *Rect) float64 {
+	sum := 0.0
+	for i := range a.P {
+		lo, hi := a.P[i], a.Q[i]
+		if b.P[i] > lo {
+			lo = b.P[i]
+		}
+		if b.Q[i] < hi {
+			hi = b.Q[i]
+		}
+		if lo > hi {
+			d := lo - hi
+			sum += d * d
+		}
+	}
+	return math.Sqrt(sum)
+}
+
+// Spherical treats Point as (latitude, longitude) in degrees and measures
+// distances along the surface of a sphere of the given Radius (meters, by
+// convention, though any unit works as long as it's used consistently).
+// Rectangles are (latMin, lonMin)-(latMax, lonMax); a rectangle "wraps"
+// the antimeridian when lonMin > lonMax, meaning its longitude interval is
+// [lonMin, 180] U [-180, lonMax].
+type Spherical struct {
+	Radius float64
+}
+
+// NewSpherical returns a Spherical metric using EarthRadiusMeters.
+func NewSpherical() Spherical {
+	return Spherical{Radius: EarthRadiusMeters}
+}
+
+func (s Spherical) radius() float64 {
+	if s.Radius > 0 {
+		return s.Radius
+	}
+	return EarthRadiusMeters
+}
+
+// haversine computes the great-circle distance between (lat1, lon1) and
+// (lat2, lon2), given in degrees.
+func (s Spherical) haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	phi1, phi2 := rad(lat1), rad(lat2)
+	dPhi := rad(lat2 - lat1)
+	dLambda := rad(lon2 - lon1)
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+	return s.radius() * c
+}
+
+// lonInRange reports whether lon falls in the (possibly antimeridian
+// wrapping) interval [lo, hi].
+func lonInRange(lon, lo, hi float64) bool {
+	if lo <= hi {
+		return lon >= lo && lon <= hi
+	}
+	return lon >= lo || lon <= hi
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// PointRect returns the great-circle distance from p=(lat,lon) to the
+// point of r at p's latitude clamped to r's latitude range: at p's own
+// longitude when that lies in r's longitude interval, otherwise at the
+// nearer of r's west and east edges.  Off those edges' latitudes this is
+// not always the nearest point of r along the great circle.
+func (s Spherical) PointRect(p Point, r *Rect) float64 {
+	lat := clamp(p[0], r.P[0], r.Q[0])
+	if lonInRange(p[1], r.P[1], r.Q[1]) {
+		return s.haversine(p[0], p[1], lat, p[1])
+	}
+	west := s.haversine(p[0], p[1], lat, r.P[1])
+	east := s.haversine(p[0], p[1], lat, r.Q[1])
+	return math.Min(west, east)
+}
+
+// RectRect approximates the great-circle distance between the nearest
+// points of a and b by sampling each rectangle's corners against the
+// other with PointRect.  It is not exact: two boxes that cross each other
+// without either holding a corner of the other get a nonzero distance.
+func (s Spherical) RectRect(a, b *Rect) float64 {
+	min := math.MaxFloat64
+	for _, c := range rectCorners(a) {
+		if d := s.PointRect(c, b); d < min {
+			min = d
+		}
+	}
+	for _, c := range rectCorners(b) {
+		if d := s.PointRect(c, a); d < min {
+			min = d
+		}
+	}
+	return min
+}
+
+func rectCorners(r *Rect) []Point {
+	return []Point{
+		{r.P[0], r.P[1]},
+		{r.P[0], r.Q[1]},
+		{r.Q[0], r.P[1]},
+		{r.Q[0], r.Q[1]},
+	}
+}
+
+// lonSpan returns the eastward angular distance from p to q, in
+// [0, 360], treating p > q as wrapping the antimeridian -- the same
+// convention Rect uses for a wrapping longitude interval.
+func lonSpan(p, q float64) float64 {
+	d := q - p
+	if d < 0 {
+		d += 360
+	}
+	return d
+}
+
+// normalizeLon brings x back into (-180, 180].
+func normalizeLon(x float64) float64 {
+	for x > 180 {
+		x -= 360
+	}
+	for x <= -180 {
+		x += 360
+	}
+	return x
+}
+
+// MergeRect combines a and b into the smallest enclosing rectangle.  a and
+// b may each already be wrapping (P[1] > Q[1]) or not; MergeRect treats
+// each as the arc [P[1], P[1]+lonSpan(P[1],Q[1])] and picks whichever of
+// the two candidate merged arcs -- starting at a's west edge or at b's --
+// is shorter, so it always contains both inputs rather than only
+// handling the case where neither wraps.
+func (s Spherical) MergeRect(a, b *Rect) (*Rect, error) {
+	if err := a.checkDims(b); err != nil {
+		return nil, err
+	}
+	r := &Rect{P: make(Point, len(a.P)), Q: make(Point, len(a.Q))}
+	r.P[0] = math.Min(a.P[0], b.P[0])
+	r.Q[0] = math.Max(a.Q[0], b.Q[0])
+
+	lenA := lonSpan(a.P[1], a.Q[1])
+	lenB := lonSpan(b.P[1], b.Q[1])
+
+	fromA := math.Max(lenA, lonSpan(a.P[1], b.P[1])+lenB)
+	fromB := math.Max(lenB, lonSpan(b.P[1], a.P[1])+lenA)
+
+	start, length := a.P[1], fromA
+	if fromB < fromA {
+		start, length = b.P[1], fromB
+	}
+
+	if length >= 360 {
+		r.P[1], r.Q[1] = -180, 180
+	} else {
+		r.P[1] = start
+		r.Q[1] = normalizeLon(start + length)
+	}
+	return r, nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2012 Daniel Connelly.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rtreego
+
+// Spatial is implemented by any value that can be indexed by a Tree: it
+// must be able to report its own minimum bounding rectangle.
+type Spatial interface {
+	Bounds() *Rect
+}
+
+// entry is a node's pointer to either a child node (interior entries) or
+// a stored object (leaf entries), along with that child's bounding box.
+type entry struct {
+	bb    *Rect
+	child *node
+	obj   Spatial
+}
+
+// node is an interior or leaf node in a Tree.
+type node struct {
+	parent  *node
+	leaf    bool
+	entries []entry
+}
+
+// level returns the number of edges between n and the root of its tree.
+func (n *node) level() int {
+	level := 0
+	for p := n.parent; p != nil; p = p.parent {
+		level++
+	}
+	return level
+}
+
+// computeBoundingBox returns the smallest rectangle that contains the
+// bounding boxes of all of n's entries.
+func (n *node) computeBoundingBox(dims int) (*Rect, error) {
+	if len(n.entries) == 0 {
+		return nil, nil
+	}
+	first := n.entries[0].bb
+	bb := Rect{P: append(Point(nil), first.P...), Q: append(Point(nil), first.Q...)}
+	for _, e := range n.entries[1:] {
+		if err := bb.enlarge(e.bb); err != nil {
+			return nil, err
+		}
+	}
+	return &bb, nil
+}
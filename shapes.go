@@ -0,0 +1,368 @@
+// Copyright 2012 Daniel Connelly.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rtreego
+
+import "math"
+
+// intersectSamples is the number of equal steps a Segment is divided into
+// (so intersectSamples+1 points, endpoints included, are sampled) when
+// SearchIntersectShape falls back to sampling against a Shape with no
+// exact intersection test; see segmentIntersectsSampled.
+const intersectSamples = 33
+
+// preciseEps is the tolerance used to decide that a sampled point lies on
+// a Shape's surface when confirming an intersection.
+const preciseEps = 1e-9
+
+// Shape is implemented by leaf geometry that can be compared to a query
+// point more precisely than its bounding box allows.  NearestNeighbor uses
+// PreciseDistance, when available, to resolve ties between candidates
+// whose MBRs are equally close to the query point -- e.g. to find which
+// triangle of a mesh a point actually falls nearest to, rather than which
+// triangle's bounding box is nearest.
+type Shape interface {
+	Spatial
+	// PreciseDistance returns the true distance from p to the shape
+	// (zero if p lies on or inside it), as opposed to the distance to
+	// its bounding box.
+	PreciseDistance(p Point) float64
+}
+
+// Segment is a line segment between two points of any dimensionality.
+type Segment struct {
+	A, B Point
+}
+
+// Bounds returns the axis-aligned bounding box of the segment.
+func (s *Segment) Bounds() *Rect {
+	return boundsOfPoints(s.A, s.B)
+}
+
+// PreciseDistance returns the distance from p to its projection onto the
+// segment, clamped to the segment's endpoints.
+func (s *Segment) PreciseDistance(p Point) float64 {
+	return p.Dist(s.closestPoint(p))
+}
+
+func (s *Segment) closestPoint(p Point) Point {
+	ab := s.B.Sub(s.A)
+	denom := ab.Dot(ab)
+	if denom == 0 {
+		return s.A
+	}
+	t := clamp(p.Sub(s.A).Dot(ab)/denom, 0, 1)
+	return s.A.Add(ab.Scale(t))
+}
+
+// Triangle is a triangle with vertices A, B, C, of any dimensionality (the
+// vertices are assumed to be coplanar, as they always are for three
+// points).
+type Triangle struct {
+	A, B, C Point
+}
+
+// Bounds returns the axis-aligned bounding box of the triangle.
+func (t *Triangle) Bounds() *Rect {
+	return boundsOfPoints(t.A, t.B, t.C)
+}
+
+// PreciseDistance returns the distance from p to its closest point on the
+// triangle, found via a barycentric containment test followed by edge
+// projection (Ericson, "Real-Time Collision Detection", ClosestPtPointTriangle).
+func (t *Triangle) PreciseDistance(p Point) float64 {
+	return p.Dist(closestPointOnTriangle(p, t.A, t.B, t.C))
+}
+
+func closestPointOnTriangle(p, a, b, c Point) Point {
+	ab := b.Sub(a)
+	ac := c.Sub(a)
+	ap := p.Sub(a)
+	d1 := ab.Dot(ap)
+	d2 := ac.Dot(ap)
+	if d1 <= 0 && d2 <= 0 {
+		return a
+	}
+
+	bp := p.Sub(b)
+	d3 := ab.Dot(bp)
+	d4 := ac.Dot(bp)
+	if d3 >= 0 && d4 <= d3 {
+		return b
+	}
+	vc := d1*d4 - d3*d2
+	if vc <= 0 && d1 >= 0 && d3 <= 0 {
+		v := d1 / (d1 - d3)
+		return a.Add(ab.Scale(v))
+	}
+
+	cp := p.Sub(c)
+	d5 := ab.Dot(cp)
+	d6 := ac.Dot(cp)
+	if d6 >= 0 && d5 <= d6 {
+		return c
+	}
+	vb := d5*d2 - d1*d6
+	if vb <= 0 && d2 >= 0 && d6 <= 0 {
+		w := d2 / (d2 - d6)
+		return a.Add(ac.Scale(w))
+	}
+
+	va := d3*d6 - d5*d4
+	if va <= 0 && (d4-d3) >= 0 && (d5-d6) >= 0 {
+		w := (d4 - d3) / ((d4 - d3) + (d5 - d6))
+		return b.Add(c.Sub(b).Scale(w))
+	}
+
+	denom := 1 / (va + vb + vc)
+	v := vb * denom
+	w := vc * denom
+	return a.Add(ab.Scale(v)).Add(ac.Scale(w))
+}
+
+// ConvexPolygon is a convex polygon in the plane, given as an ordered
+// (clockwise or counter-clockwise) list of vertices.
+type ConvexPolygon struct {
+	Vertices []Point
+}
+
+// Bounds returns the axis-aligned bounding box of the polygon.
+func (poly *ConvexPolygon) Bounds() *Rect {
+	return boundsOfPoints(poly.Vertices...)
+}
+
+// PreciseDistance returns zero if p is inside (or on the boundary of) the
+// polygon, and otherwise the distance from p to its nearest edge.
+func (poly *ConvexPolygon) PreciseDistance(p Point) float64 {
+	if poly.contains(p) {
+		return 0
+	}
+	min := -1.0
+	n := len(poly.Vertices)
+	for i := 0; i < n; i++ {
+		edge := Segment{A: poly.Vertices[i], B: poly.Vertices[(i+1)%n]}
+		if d := edge.PreciseDistance(p); min < 0 || d < min {
+			min = d
+		}
+	}
+	return min
+}
+
+// contains reports whether p is inside or on the boundary of the polygon,
+// using a cross-product sign test valid for convex, consistently-wound
+// vertex lists.
+func (poly *ConvexPolygon) contains(p Point) bool {
+	n := len(poly.Vertices)
+	sign := 0
+	for i := 0; i < n; i++ {
+		a := poly.Vertices[i]
+		b := poly.Vertices[(i+1)%n]
+		cross := (b[0]-a[0])*(p[1]-a[1]) - (b[1]-a[1])*(p[0]-a[0])
+		switch {
+		case cross > 0:
+			if sign < 0 {
+				return false
+			}
+			sign = 1
+		case cross < 0:
+			if sign > 0 {
+				return false
+			}
+			sign = -1
+		}
+	}
+	return true
+}
+
+// boundsOfPoints returns the smallest Rect containing every point in pts.
+func boundsOfPoints(pts ...Point) *Rect {
+	dims := len(pts[0])
+	lo := make(Point, dims)
+	hi := make(Point, dims)
+	copy(lo, pts[0])
+	copy(hi, pts[0])
+	for _, p := range pts[1:] {
+		for i := 0; i < dims; i++ {
+			if p[i] < lo[i] {
+				lo[i] = p[i]
+			}
+			if p[i] > hi[i] {
+				hi[i] = p[i]
+			}
+		}
+	}
+	r, _ := NewRectFromCorners(lo, hi)
+	// Degenerate (zero-area) shapes are common -- a segment, or a
+	// triangle lying in a coordinate plane -- so nudge empty sides open;
+	// NewRectFromCorners, unlike NewRect, tolerates the zero-length
+	// sides that produces before this fix-up runs.
+	for i := 0; i < dims; i++ {
+		if r.Q[i] <= r.P[i] {
+			r.Q[i] = r.P[i] + 1e-10
+		}
+	}
+	return r
+}
+
+// SearchIntersectShape returns every object in t that may intersect shape.
+// Candidates are first narrowed using shape's bounding box via
+// SearchIntersect; a candidate is then confirmed only when it also
+// implements Shape. When shape is a Segment and the candidate is a
+// Triangle or ConvexPolygon, confirmation is an exact segment/plane or
+// segment/edge intersection test -- this is the "vertical line through a
+// mesh" slicing query the request names, and needs to be exact rather than
+// approximate to find the thin sliver a typical cutting plane passes
+// through. For any other Shape implementor, confirmation falls back to
+// sampling points along the segment and testing them against the
+// candidate's PreciseDistance, since Shape exposes only point-to-shape
+// distance there.
+func (t *Tree) SearchIntersectShape(shape Shape) ([]Spatial, error) {
+	candidates, err := t.SearchIntersect(shape.Bounds())
+	if err != nil {
+		return nil, err
+	}
+
+	seg, isSegment := shape.(*Segment)
+	var hits []Spatial
+	for _, obj := range candidates {
+		target, ok := obj.(Shape)
+		if !ok || !isSegment {
+			// Without a segment to test or a precise shape to test
+			// against, fall back to the bounding-box match already
+			// confirmed by SearchIntersect.
+			hits = append(hits, obj)
+			continue
+		}
+		if segmentIntersectsShape(seg, target) {
+			hits = append(hits, obj)
+		}
+	}
+	return hits, nil
+}
+
+// segmentIntersectsShape confirms whether seg intersects target, using an
+// exact test for the shapes SearchIntersectShape's doc comment promises one
+// for, and falling back to sampling for any other Shape implementor.
+func segmentIntersectsShape(seg *Segment, target Shape) bool {
+	switch s := target.(type) {
+	case *Triangle:
+		return segmentIntersectsTriangle(seg, s)
+	case *ConvexPolygon:
+		return segmentIntersectsPolygon(seg, s)
+	default:
+		return segmentIntersectsSampled(seg, target)
+	}
+}
+
+// segmentIntersectsTriangle reports whether seg crosses the plane of a
+// (3-dimensional) triangle within the triangle itself, via the
+// Moller-Trumbore ray/triangle intersection algorithm restricted to the
+// segment's own parameter range.
+func segmentIntersectsTriangle(seg *Segment, tri *Triangle) bool {
+	e1 := tri.B.Sub(tri.A)
+	e2 := tri.C.Sub(tri.A)
+	d := seg.B.Sub(seg.A)
+
+	h := cross3(d, e2)
+	a := e1.Dot(h)
+	if math.Abs(a) < preciseEps {
+		return false // segment is parallel to the triangle's plane
+	}
+
+	f := 1 / a
+	s := seg.A.Sub(tri.A)
+	u := f * s.Dot(h)
+	if u < -preciseEps || u > 1+preciseEps {
+		return false
+	}
+
+	q := cross3(s, e1)
+	v := f * d.Dot(q)
+	if v < -preciseEps || u+v > 1+preciseEps {
+		return false
+	}
+
+	param := f * e2.Dot(q)
+	return param >= -preciseEps && param <= 1+preciseEps
+}
+
+// cross3 returns the cross product of two 3-dimensional points.
+func cross3(a, b Point) Point {
+	return Point{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+// segmentIntersectsPolygon reports whether seg (in the same plane as poly,
+// per ConvexPolygon's own doc comment) crosses poly's boundary or has an
+// endpoint inside it.
+func segmentIntersectsPolygon(seg *Segment, poly *ConvexPolygon) bool {
+	if poly.contains(seg.A) || poly.contains(seg.B) {
+		return true
+	}
+	n := len(poly.Vertices)
+	for i := 0; i < n; i++ {
+		edge := Segment{A: poly.Vertices[i], B: poly.Vertices[(i+1)%n]}
+		if segmentsIntersect2D(seg, &edge) {
+			return true
+		}
+	}
+	return false
+}
+
+// orient2D returns twice the signed area of triangle (a, b, c): positive if
+// c is left of the line a->b, negative if right, zero if collinear.
+func orient2D(a, b, c Point) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+// onSegment2D reports whether c, already known to be collinear with a-b,
+// falls within a-b's bounding box (and so on the segment itself).
+func onSegment2D(a, b, c Point) bool {
+	return math.Min(a[0], b[0])-preciseEps <= c[0] && c[0] <= math.Max(a[0], b[0])+preciseEps &&
+		math.Min(a[1], b[1])-preciseEps <= c[1] && c[1] <= math.Max(a[1], b[1])+preciseEps
+}
+
+// segmentsIntersect2D reports whether two 2D segments share a point, via
+// the standard orientation-sign test (with a fallback for collinear
+// overlap).
+func segmentsIntersect2D(s1, s2 *Segment) bool {
+	d1 := orient2D(s2.A, s2.B, s1.A)
+	d2 := orient2D(s2.A, s2.B, s1.B)
+	d3 := orient2D(s1.A, s1.B, s2.A)
+	d4 := orient2D(s1.A, s1.B, s2.B)
+
+	if ((d1 > 0) != (d2 > 0)) && ((d3 > 0) != (d4 > 0)) {
+		return true
+	}
+	if d1 == 0 && onSegment2D(s2.A, s2.B, s1.A) {
+		return true
+	}
+	if d2 == 0 && onSegment2D(s2.A, s2.B, s1.B) {
+		return true
+	}
+	if d3 == 0 && onSegment2D(s1.A, s1.B, s2.A) {
+		return true
+	}
+	if d4 == 0 && onSegment2D(s1.A, s1.B, s2.B) {
+		return true
+	}
+	return false
+}
+
+// segmentIntersectsSampled is the original sampled approximation, kept as a
+// fallback for Shape implementors other than Triangle/ConvexPolygon, which
+// expose no more than point-to-shape distance to test against.
+func segmentIntersectsSampled(seg *Segment, target Shape) bool {
+	for i := 0; i <= intersectSamples; i++ {
+		frac := float64(i) / intersectSamples
+		p := seg.A.Add(seg.B.Sub(seg.A).Scale(frac))
+		if target.PreciseDistance(p) <= preciseEps {
+			return true
+		}
+	}
+	return false
+}
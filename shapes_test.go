@@ -0,0 +1,116 @@
+// Copyright 2012 Daniel Connelly.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rtreego
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTrianglePreciseDistance(t *testing.T) {
+	tri := &Triangle{A: Point{0, 0, 0}, B: Point{1, 0, 0}, C: Point{0, 1, 0}}
+
+	if d := tri.PreciseDistance(Point{0.25, 0.25, 0}); d > 1e-9 {
+		t.Errorf("point inside the triangle: PreciseDistance = %v, want ~0", d)
+	}
+
+	// Straight above an interior point: closest point is that point's
+	// projection onto the triangle's own plane, at distance exactly h.
+	h := 2.0
+	if d := tri.PreciseDistance(Point{0.25, 0.25, h}); math.Abs(d-h) > 1e-9 {
+		t.Errorf("point above the triangle: PreciseDistance = %v, want %v", d, h)
+	}
+
+	// Outside the triangle entirely, closest to vertex A.
+	if d := tri.PreciseDistance(Point{-3, -4, 0}); math.Abs(d-5) > 1e-9 {
+		t.Errorf("point outside near A: PreciseDistance = %v, want 5", d)
+	}
+}
+
+func TestConvexPolygonPreciseDistance(t *testing.T) {
+	square := &ConvexPolygon{Vertices: []Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}}
+
+	if d := square.PreciseDistance(Point{1, 1}); d != 0 {
+		t.Errorf("point inside the square: PreciseDistance = %v, want 0", d)
+	}
+	if d := square.PreciseDistance(Point{0, 0}); d != 0 {
+		t.Errorf("point on a vertex: PreciseDistance = %v, want 0", d)
+	}
+	if d := square.PreciseDistance(Point{5, 1}); math.Abs(d-3) > 1e-9 {
+		t.Errorf("point outside, nearest the right edge: PreciseDistance = %v, want 3", d)
+	}
+}
+
+func TestSearchIntersectShapeSegmentTriangle(t *testing.T) {
+	tree, err := NewTree(3, 2, 5)
+	if err != nil {
+		t.Fatalf("NewTree: %v", err)
+	}
+	tri := &Triangle{A: Point{-1, -1, 0}, B: Point{1, -1, 0}, C: Point{0, 1, 0}}
+	if err := tree.Insert(tri); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	// A vertical segment straight through the triangle's interior: the
+	// textbook mesh-slicing query this method exists for.
+	through := &Segment{A: Point{0, -0.33, -5}, B: Point{0, -0.33, 5}}
+	hits, err := tree.SearchIntersectShape(through)
+	if err != nil {
+		t.Fatalf("SearchIntersectShape: %v", err)
+	}
+	if len(hits) != 1 || hits[0] != Spatial(tri) {
+		t.Errorf("segment through the triangle: got %v, want [tri]", hits)
+	}
+
+	// A vertical segment well outside the triangle's bounding box.
+	miss := &Segment{A: Point{5, 5, -5}, B: Point{5, 5, 5}}
+	hits, err = tree.SearchIntersectShape(miss)
+	if err != nil {
+		t.Fatalf("SearchIntersectShape: %v", err)
+	}
+	if len(hits) != 0 {
+		t.Errorf("segment outside the triangle: got %v, want none", hits)
+	}
+
+	// A vertical segment that stays above the triangle's plane (inside
+	// its bounding box in x/y, but never reaching z=0).
+	above := &Segment{A: Point{0, -0.33, 1}, B: Point{0, -0.33, 5}}
+	hits, err = tree.SearchIntersectShape(above)
+	if err != nil {
+		t.Fatalf("SearchIntersectShape: %v", err)
+	}
+	if len(hits) != 0 {
+		t.Errorf("segment above the triangle's plane: got %v, want none", hits)
+	}
+}
+
+func TestSearchIntersectShapeSegmentPolygon(t *testing.T) {
+	tree, err := NewTree(2, 2, 5)
+	if err != nil {
+		t.Fatalf("NewTree: %v", err)
+	}
+	square := &ConvexPolygon{Vertices: []Point{{0, 0}, {2, 0}, {2, 2}, {0, 2}}}
+	if err := tree.Insert(square); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+
+	crossing := &Segment{A: Point{1, -1}, B: Point{1, 3}}
+	hits, err := tree.SearchIntersectShape(crossing)
+	if err != nil {
+		t.Fatalf("SearchIntersectShape: %v", err)
+	}
+	if len(hits) != 1 {
+		t.Errorf("segment crossing the square: got %v, want [square]", hits)
+	}
+
+	miss := &Segment{A: Point{10, -1}, B: Point{10, 3}}
+	hits, err = tree.SearchIntersectShape(miss)
+	if err != nil {
+		t.Fatalf("SearchIntersectShape: %v", err)
+	}
+	if len(hits) != 0 {
+		t.Errorf("segment missing the square: got %v, want none", hits)
+	}
+}
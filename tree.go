@@ -0,0 +1,428 @@
+// Copyright 2012 Daniel Connelly.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rtreego
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultMinChildren and DefaultMaxChildren are the node fan-out bounds
+// BulkLoad uses; NewTree always takes its fan-out bounds from its caller.
+const (
+	DefaultMinChildren = 2
+	DefaultMaxChildren = 5
+)
+
+// Tree represents an R-tree, a balanced search tree for storing and
+// querying spatial objects.  Unlike earlier versions of this package, a
+// Tree's dimensionality is a runtime property (Dims) rather than a
+// compile-time constant, so a single process can maintain trees of
+// different dimensionalities (e.g. 2D and 3D) side by side.
+type Tree struct {
+	Dims                     int
+	MinChildren, MaxChildren int
+	root                     *node
+	size                     int
+	metric                   Metric
+	tolerance                float64
+}
+
+// TreeOption configures optional behavior on a Tree at construction time.
+// See WithMetric and WithTolerance.
+type TreeOption func(*Tree)
+
+// WithMetric selects the distance Metric a Tree uses for NearestNeighbor
+// and range-query pruning.  Without this option a Tree uses Euclidean.
+func WithMetric(m Metric) TreeOption {
+	return func(t *Tree) {
+		t.metric = m
+	}
+}
+
+// WithTolerance sets the slack, in coordinate units, that this Tree's own
+// queries (currently SearchIntersect) use in place of the package-level
+// Tolerance variable, so that one tree can be more forgiving of
+// near-misses than another in the same process.
+func WithTolerance(eps float64) TreeOption {
+	return func(t *Tree) {
+		t.tolerance = eps
+	}
+}
+
+// NewTree creates a new R-tree instance for indexing objects of the given
+// dimensionality.  It returns an error if dims is not positive or the
+// fan-out bounds are invalid.
+func NewTree(dims, minChildren, maxChildren int, opts ...TreeOption) (*Tree, error) {
+	if dims <= 0 {
+		return nil, DimError{1, dims}
+	}
+	if minChildren <= 0 || maxChildren < 2*minChildren {
+		return nil, DistError(float64(maxChildren))
+	}
+	t := &Tree{
+		Dims:        dims,
+		MinChildren: minChildren,
+		MaxChildren: maxChildren,
+		root:        &node{leaf: true},
+		metric:      Euclidean{},
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t, nil
+}
+
+// mergeRect combines a and b into their bounding box, deferring to the
+// tree's Metric when it knows a better way to do so (e.g. Spherical
+// picking the shorter of two antimeridian-wrapping longitude spans).
+func (t *Tree) mergeRect(a, b *Rect) (*Rect, error) {
+	if merger, ok := t.metric.(RectMerger); ok {
+		return merger.MergeRect(a, b)
+	}
+	if err := a.checkDims(b); err != nil {
+		return nil, err
+	}
+	return a.Union(b), nil
+}
+
+// Size returns the number of objects currently stored in t.
+func (t *Tree) Size() int {
+	return t.size
+}
+
+// checkDims returns a DimError if obj's bounding box does not match the
+// tree's declared dimensionality.
+func (t *Tree) checkDims(bb *Rect) error {
+	if bb.Dims() != t.Dims {
+		return DimError{t.Dims, bb.Dims()}
+	}
+	return nil
+}
+
+// Insert adds obj to t.  It returns an error, rather than panicking, if
+// obj's bounding box does not match the tree's dimensionality.
+func (t *Tree) Insert(obj Spatial) error {
+	bb := obj.Bounds()
+	if err := t.checkDims(bb); err != nil {
+		return err
+	}
+	e := entry{bb: bb, obj: obj}
+	leaf, err := t.chooseLeaf(t.root, e)
+	if err != nil {
+		return err
+	}
+	leaf.entries = append(leaf.entries, e)
+	t.size++
+	return t.adjustTree(leaf)
+}
+
+// chooseLeaf selects the leaf node under n in which e should be inserted,
+// following the entry whose bounding box requires the least enlargement
+// to contain e at every level (Guttman's ChooseLeaf).
+func (t *Tree) chooseLeaf(n *node, e entry) (*node, error) {
+	if n.leaf {
+		return n, nil
+	}
+	best := -1
+	var bestEnlargement float64
+	for i, c := range n.entries {
+		enlarged, err := t.mergeRect(c.bb, e.bb)
+		if err != nil {
+			return nil, err
+		}
+		d := enlarged.size() - c.bb.size()
+		if best == -1 || d < bestEnlargement {
+			best = i
+			bestEnlargement = d
+		}
+	}
+	return t.chooseLeaf(n.entries[best].child, e)
+}
+
+// adjustTree walks from n up to the root, enlarging bounding boxes and
+// splitting overfull nodes along the way.
+func (t *Tree) adjustTree(n *node) error {
+	for n != nil {
+		if len(n.entries) > t.MaxChildren {
+			split, err := t.splitNode(n)
+			if err != nil {
+				return err
+			}
+			if n.parent == nil {
+				root := &node{entries: []entry{
+					{bb: mustBounds(n), child: n},
+					{bb: mustBounds(split), child: split},
+				}}
+				n.parent = root
+				split.parent = root
+				t.root = root
+				return nil
+			}
+			if err := replaceChild(n.parent, n, split); err != nil {
+				return err
+			}
+		} else if n.parent != nil {
+			if err := updateBoundingBox(n.parent, n); err != nil {
+				return err
+			}
+		}
+		n = n.parent
+	}
+	return nil
+}
+
+// splitNode performs a simple quadratic-cost split of an overfull node,
+// distributing its entries between n and a freshly created sibling.
+func (t *Tree) splitNode(n *node) (*node, error) {
+	entries := n.entries
+	split := &node{leaf: n.leaf, parent: n.parent}
+	n.entries = nil
+	// Seed the two groups with the pair of entries whose combined
+	// bounding box wastes the most area (Guttman's PickSeeds).
+	seed1, seed2, worst := 0, 1, -1.0
+	for i := 0; i < len(entries); i++ {
+		for j := i + 1; j < len(entries); j++ {
+			bb, err := t.mergeRect(entries[i].bb, entries[j].bb)
+			if err != nil {
+				return nil, err
+			}
+			waste := bb.size() - entries[i].bb.size() - entries[j].bb.size()
+			if waste > worst {
+				worst, seed1, seed2 = waste, i, j
+			}
+		}
+	}
+	n.entries = append(n.entries, entries[seed1])
+	split.entries = append(split.entries, entries[seed2])
+
+	var remaining []entry
+	for i, e := range entries {
+		if i != seed1 && i != seed2 {
+			remaining = append(remaining, e)
+		}
+	}
+
+	// Distribute the rest, one at a time, by least enlargement -- except
+	// once a group can only just reach MinChildren by taking everything
+	// that's left, force the rest into it rather than risk an underfull
+	// node (Guttman's forced-fill rule).
+	for len(remaining) > 0 {
+		if len(n.entries)+len(remaining) <= t.MinChildren {
+			n.entries = append(n.entries, remaining...)
+			break
+		}
+		if len(split.entries)+len(remaining) <= t.MinChildren {
+			split.entries = append(split.entries, remaining...)
+			break
+		}
+
+		e := remaining[0]
+		remaining = remaining[1:]
+
+		nBB, err := n.computeBoundingBox(t.Dims)
+		if err != nil {
+			return nil, err
+		}
+		sBB, err := split.computeBoundingBox(t.Dims)
+		if err != nil {
+			return nil, err
+		}
+		enlN, err := t.mergeRect(nBB, e.bb)
+		if err != nil {
+			return nil, err
+		}
+		enlS, err := t.mergeRect(sBB, e.bb)
+		if err != nil {
+			return nil, err
+		}
+		if enlN.size()-nBB.size() <= enlS.size()-sBB.size() {
+			n.entries = append(n.entries, e)
+		} else {
+			split.entries = append(split.entries, e)
+		}
+	}
+
+	for _, e := range n.entries {
+		if e.child != nil {
+			e.child.parent = n
+		}
+	}
+	for _, e := range split.entries {
+		if e.child != nil {
+			e.child.parent = split
+		}
+	}
+	return split, nil
+}
+
+func mustBounds(n *node) *Rect {
+	bb, _ := n.computeBoundingBox(0)
+	return bb
+}
+
+func replaceChild(parent, old, split *node) error {
+	for i, e := range parent.entries {
+		if e.child == old {
+			bb, err := old.computeBoundingBox(0)
+			if err != nil {
+				return err
+			}
+			parent.entries[i].bb = bb
+			parent.entries = append(parent.entries, entry{bb: mustBounds(split), child: split})
+			return nil
+		}
+	}
+	return nil
+}
+
+func updateBoundingBox(parent, n *node) error {
+	bb, err := n.computeBoundingBox(0)
+	if err != nil || bb == nil {
+		return err
+	}
+	for i, e := range parent.entries {
+		if e.child == n {
+			parent.entries[i].bb = bb
+		}
+	}
+	return nil
+}
+
+// NearestNeighbor returns the object in t closest to p, using the
+// branch-and-bound pruning strategy of Roussopoulos et al.  Leaf entries
+// whose MBR minDist is under the current best are not confirmed by their
+// MBR alone: when the stored object implements Shape, its PreciseDistance
+// is used instead, so objects like triangles or polygons are compared by
+// their true geometry rather than by their bounding box.
+func (t *Tree) NearestNeighbor(p Point) (Spatial, error) {
+	if p.Dims() != t.Dims {
+		return nil, DimError{t.Dims, p.Dims()}
+	}
+	var best Spatial
+	bestDist := math.MaxFloat64
+	var visit func(n *node) error
+	visit = func(n *node) error {
+		for _, e := range n.entries {
+			d := t.metric.PointRect(p, e.bb)
+			if d > bestDist {
+				continue
+			}
+			if n.leaf {
+				if shape, ok := e.obj.(Shape); ok {
+					d = shape.PreciseDistance(p)
+				}
+				if d < bestDist {
+					bestDist = d
+					best = e.obj
+				}
+			} else if err := visit(e.child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := visit(t.root); err != nil {
+		return nil, err
+	}
+	return best, nil
+}
+
+// NearestNeighbors returns up to k objects in t closest to p, nearest
+// first. Unlike NearestNeighbor, which only ever measures an internal
+// node's children from the query point itself, this orders and prunes
+// internal entries by the Metric's RectRect distance between each
+// child's bounding box and p's (zero-size) query rect -- the rect-to-rect
+// pruning bound the Metric interface exists to provide for k-NN search --
+// so the most promising subtrees are explored, and the rest discarded,
+// before any leaf distance is computed.
+func (t *Tree) NearestNeighbors(k int, p Point) ([]Spatial, error) {
+	if p.Dims() != t.Dims {
+		return nil, DimError{t.Dims, p.Dims()}
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+
+	queryRect := p.ToRect(0)
+	type result struct {
+		obj  Spatial
+		dist float64
+	}
+	var best []result
+
+	var visit func(n *node) error
+	visit = func(n *node) error {
+		type scored struct {
+			e entry
+			d float64
+		}
+		ordered := make([]scored, len(n.entries))
+		for i, e := range n.entries {
+			ordered[i] = scored{e, t.metric.RectRect(queryRect, e.bb)}
+		}
+		sort.Slice(ordered, func(i, j int) bool { return ordered[i].d < ordered[j].d })
+
+		for _, se := range ordered {
+			if len(best) >= k && se.d > best[len(best)-1].dist {
+				continue
+			}
+			if n.leaf {
+				d := t.metric.PointRect(p, se.e.bb)
+				if shape, ok := se.e.obj.(Shape); ok {
+					d = shape.PreciseDistance(p)
+				}
+				best = append(best, result{se.e.obj, d})
+				sort.Slice(best, func(i, j int) bool { return best[i].dist < best[j].dist })
+				if len(best) > k {
+					best = best[:k]
+				}
+			} else if err := visit(se.e.child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := visit(t.root); err != nil {
+		return nil, err
+	}
+
+	out := make([]Spatial, len(best))
+	for i, r := range best {
+		out[i] = r.obj
+	}
+	return out, nil
+}
+
+// SearchIntersect returns every object in t whose bounding box overlaps
+// bb, allowing near-misses within the tree's tolerance (see WithTolerance).
+func (t *Tree) SearchIntersect(bb *Rect) ([]Spatial, error) {
+	if err := t.checkDims(bb); err != nil {
+		return nil, err
+	}
+	var results []Spatial
+	var visit func(n *node) error
+	visit = func(n *node) error {
+		for _, e := range n.entries {
+			ok, err := intersects(e.bb, bb, t.tolerance)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			if n.leaf {
+				results = append(results, e.obj)
+			} else if err := visit(e.child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := visit(t.root); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2012 Daniel Connelly.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rtreego
+
+import (
+	"math"
+	"testing"
+)
+
+// point2D is a minimal Spatial backed by a tiny (1e-9-sided) Rect at the
+// given coordinates, since NewRect rejects zero lengths; it is used to
+// exercise Tree without a real dataset.
+type point2D struct {
+	x, y float64
+}
+
+func (p point2D) Bounds() *Rect {
+	r, err := NewRect(Point{p.x, p.y}, []float64{1e-9, 1e-9})
+	if err != nil {
+		panic(err)
+	}
+	return &r
+}
+
+func TestSplitNodeEnforcesMinChildren(t *testing.T) {
+	tree, err := NewTree(2, 3, 6)
+	if err != nil {
+		t.Fatalf("NewTree: %v", err)
+	}
+
+	// A tight cluster of points plus one distant outlier: PickSeeds will
+	// choose the outlier as one seed, and without forced-fill every
+	// other point (being closer to the cluster) would be assigned to
+	// the other seed's group, leaving the outlier's group with just 1
+	// entry -- fewer than MinChildren.
+	objs := []Spatial{
+		point2D{0, 0}, point2D{0.1, 0}, point2D{0, 0.1},
+		point2D{0.1, 0.1}, point2D{0.05, 0.05}, point2D{100, 100},
+		point2D{0.2, 0}, point2D{0, 0.2},
+	}
+	for _, o := range objs {
+		if err := tree.Insert(o); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n != tree.root && !n.leaf && len(n.entries) < tree.MinChildren {
+			t.Errorf("internal node has %d entries, want >= MinChildren (%d)", len(n.entries), tree.MinChildren)
+		}
+		if n != tree.root && n.leaf && len(n.entries) < tree.MinChildren {
+			t.Errorf("leaf node has %d entries, want >= MinChildren (%d)", len(n.entries), tree.MinChildren)
+		}
+		for _, e := range n.entries {
+			if e.child != nil {
+				walk(e.child)
+			}
+		}
+	}
+	walk(tree.root)
+}
+
+func TestNearestNeighbors(t *testing.T) {
+	tree, err := NewTree(2, 2, 5)
+	if err != nil {
+		t.Fatalf("NewTree: %v", err)
+	}
+	pts := []point2D{{0, 0}, {1, 1}, {2, 2}, {10, 10}, {-1, -1}}
+	for _, p := range pts {
+		if err := tree.Insert(p); err != nil {
+			t.Fatalf("Insert: %v", err)
+		}
+	}
+
+	got, err := tree.NearestNeighbors(3, Point{0, 0})
+	if err != nil {
+		t.Fatalf("NearestNeighbors: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d neighbors, want 3", len(got))
+	}
+	if p, ok := got[0].(point2D); !ok || p.x != 0 || p.y != 0 {
+		t.Errorf("nearest neighbor = %v, want (0,0)", got[0])
+	}
+}
+
+func TestSphericalHaversineKnownDistance(t *testing.T) {
+	// Two points on the equator, 90 degrees of longitude apart, are a
+	// quarter of the Earth's circumference away from each other.
+	s := NewSpherical()
+	d := s.haversine(0, 0, 0, 90)
+	want := math.Pi / 2 * s.radius()
+	if math.Abs(d-want) > 1 {
+		t.Errorf("haversine(equator, 90 deg apart) = %v, want ~%v", d, want)
+	}
+}
+
+func TestSphericalMergeRectWrap(t *testing.T) {
+	s := NewSpherical()
+	// wrap's longitude interval is [170, 180] U [-180, -170]; normal's is
+	// [-5, 5]. Neither is the degenerate (zero-width) case the original
+	// implementation assumed.
+	wrap := &Rect{P: Point{-10, 170}, Q: Point{10, -170}}
+	normal := mustRect(t, Point{-5, -5}, []float64{10, 10})
+
+	merged, err := s.MergeRect(wrap, &normal)
+	if err != nil {
+		t.Fatalf("MergeRect: %v", err)
+	}
+
+	// The merged longitude interval must still cover every longitude
+	// either input covered -- a merge that drops one input silently
+	// loses indexed geometry near the antimeridian.
+	for _, lon := range []float64{170, 179, -179, -170, -5, 0, 5} {
+		if !lonInRange(lon, merged.P[1], merged.Q[1]) {
+			t.Errorf("merged longitude interval [%v, %v] does not cover %v", merged.P[1], merged.Q[1], lon)
+		}
+	}
+
+	// And it should cover no more than it needs to: the smallest arc
+	// holding both runs east from 170 through the antimeridian to 5,
+	// 195 degrees; wrapping the long way around would be wrong.
+	span := lonSpan(merged.P[1], merged.Q[1])
+	if span > 200 {
+		t.Errorf("merged longitude span = %v, want the shorter ~195 degree arc", span)
+	}
+}